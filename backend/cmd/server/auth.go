@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionTTL is how long an issued session token remains valid.
+const sessionTTL = 24 * time.Hour
+
+// ctxUserIDKey is the context key used to stash the authenticated user ID
+// for the lifetime of a request.
+type ctxKey string
+
+const ctxUserIDKey ctxKey = "userID"
+
+// User represents a principal that can log in and own pages.
+type User struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+}
+
+// Session is an issued, signed login session tied to a User.
+type Session struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"userId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// authSecret signs and verifies session tokens. It is read from the
+// AUTH_SECRET environment variable; if unset a random secret is generated
+// for the lifetime of the process and a warning is logged, since restarting
+// the server will then invalidate all existing sessions.
+var authSecret = loadAuthSecret()
+
+func loadAuthSecret() []byte {
+	if s := os.Getenv("AUTH_SECRET"); s != "" {
+		return []byte(s)
+	}
+	log.Printf("AUTH_SECRET not set; generating an ephemeral secret (sessions will not survive a restart)")
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("failed to generate auth secret: %v", err)
+	}
+	return buf
+}
+
+// randomHexToken returns a random hex-encoded token of n random bytes,
+// suitable for webhook tokens and secrets.
+func randomHexToken(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("failed to generate random token: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// users holds registered users in memory, keyed by username.
+var (
+	usersLock sync.RWMutex
+	users     = make(map[string]User)
+)
+
+func init() {
+	// Seed an admin account so the server is usable out of the box.
+	// AUTH_ADMIN_PASSWORD is never defaulted to a fixed, well-known value:
+	// if it's unset we generate a random one and log it once, the same way
+	// loadAuthSecret handles AUTH_SECRET, so a deployment never ships with a
+	// guessable admin/admin credential.
+	username := os.Getenv("AUTH_ADMIN_USER")
+	if username == "" {
+		username = "admin"
+	}
+	password := os.Getenv("AUTH_ADMIN_PASSWORD")
+	if password == "" {
+		password = randomHexToken(16)
+		log.Printf("AUTH_ADMIN_PASSWORD not set; generated a random password for admin user %q: %s (set AUTH_ADMIN_PASSWORD to control it across restarts)", username, password)
+	}
+	if err := registerUser(username, password); err != nil {
+		log.Fatalf("failed to seed admin account: %v", err)
+	}
+}
+
+// registerUser adds a new username to the user store, hashing password with
+// bcrypt. It backs both the default admin seeding above and
+// handleCreateUser, the only two ways this server's single-process user
+// store is ever populated. It refuses to replace an existing username, since
+// this server has no notion of account ownership beyond "knows the current
+// password" — without that check, any authenticated user could re-register
+// someone else's username (e.g. "admin") with a password of their choosing.
+func registerUser(username, password string) error {
+	if username == "" || password == "" {
+		return errors.New("username and password are required")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	usersLock.Lock()
+	defer usersLock.Unlock()
+	if _, exists := users[username]; exists {
+		return fmt.Errorf("username %q is already taken", username)
+	}
+	users[username] = User{ID: username, Username: username, PasswordHash: string(hash)}
+	return nil
+}
+
+// authenticate verifies a username/password pair and returns the matching User.
+func authenticate(username, password string) (User, error) {
+	usersLock.RLock()
+	user, ok := users[username]
+	usersLock.RUnlock()
+	if !ok {
+		return User{}, errors.New("invalid credentials")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return User{}, errors.New("invalid credentials")
+	}
+	return user, nil
+}
+
+// issueSession creates a signed session token for userID, valid for sessionTTL.
+// The token is "<base64(userID|expiresUnix)>.<hex hmac>" so it can be verified
+// without server-side session storage.
+func issueSession(userID string) Session {
+	expiresAt := time.Now().Add(sessionTTL)
+	payload := fmt.Sprintf("%s|%d", userID, expiresAt.Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, authSecret)
+	mac.Write([]byte(encoded))
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	return Session{
+		Token:     encoded + "." + sig,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// parseSession validates a session token and returns the Session it encodes.
+func parseSession(token string) (Session, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return Session{}, errors.New("malformed session token")
+	}
+	encoded, sig := parts[0], parts[1]
+
+	mac := hmac.New(sha256.New, authSecret)
+	mac.Write([]byte(encoded))
+	expectedSig := hex.EncodeToString(mac.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(sig), []byte(expectedSig)) != 1 {
+		return Session{}, errors.New("invalid session signature")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Session{}, errors.New("malformed session token")
+	}
+	fields := strings.SplitN(string(raw), "|", 2)
+	if len(fields) != 2 {
+		return Session{}, errors.New("malformed session token")
+	}
+	expiresUnix, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Session{}, errors.New("malformed session token")
+	}
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		return Session{}, errors.New("session expired")
+	}
+
+	return Session{Token: token, UserID: fields[0], ExpiresAt: expiresAt}, nil
+}
+
+// extractToken pulls a session token off an incoming request, checking (in
+// order) the Authorization header, the "token" query parameter, and the
+// Sec-WebSocket-Protocol header (used by browser WebSocket clients, which
+// cannot set arbitrary headers during the handshake).
+func extractToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if strings.HasPrefix(auth, "Bearer ") {
+			return strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	if tok := r.URL.Query().Get("token"); tok != "" {
+		return tok
+	}
+	if proto := r.Header.Get("Sec-WebSocket-Protocol"); proto != "" {
+		parts := strings.Split(proto, ",")
+		for _, p := range parts {
+			p = strings.TrimSpace(p)
+			if p != "" && p != "bearer" {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// requireAuth wraps an http.HandlerFunc so it only runs for requests bearing
+// a valid session token, stashing the authenticated user ID in the request
+// context for downstream handlers.
+func requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := extractToken(r)
+		if token == "" {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		session, err := parseSession(token)
+		if err != nil {
+			http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+			return
+		}
+		ctx := contextWithUserID(r.Context(), session.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+func contextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, ctxUserIDKey, userID)
+}
+
+func userIDFromRequest(r *http.Request) string {
+	v, _ := r.Context().Value(ctxUserIDKey).(string)
+	return v
+}
+
+// handleLogin authenticates a username/password pair and returns a signed
+// session token on success.
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := authenticate(creds.Username, creds.Password)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	session := issueSession(user.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// handleCreateUser provisions a new account, so a deployment isn't stuck
+// with only the seeded admin account for its whole lifetime. It sits behind
+// requireAuth rather than a separate admin role: this server has no
+// per-user permission levels, so any already-authenticated user is already
+// as privileged as the seeded admin.
+func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := registerUser(creds.Username, creds.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "created", "username": creds.Username})
+}
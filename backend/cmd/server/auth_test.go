@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+// TestRegisterUserRefusesDuplicateUsername covers the account-takeover bug
+// review comment (chunk0-1) surfaced: handleCreateUser has no admin check,
+// so if registerUser allowed overwriting an existing username, any
+// authenticated caller could re-register "admin" with a password of their
+// choosing and inherit that account's page ownership.
+func TestRegisterUserRefusesDuplicateUsername(t *testing.T) {
+	usersLock.Lock()
+	users = make(map[string]User)
+	usersLock.Unlock()
+
+	if err := registerUser("alice", "first-password"); err != nil {
+		t.Fatalf("registerUser: %v", err)
+	}
+
+	if err := registerUser("alice", "attacker-password"); err == nil {
+		t.Fatal("expected registerUser to refuse an already-taken username")
+	}
+
+	if _, err := authenticate("alice", "first-password"); err != nil {
+		t.Fatalf("original password should still work after the rejected re-registration: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Aryan181/server-ui/backend/legacy"
+	"github.com/Aryan181/server-ui/backend/metrics"
+)
+
+// clientSendBuffer bounds how many outbound frames a client's writePump will
+// queue before it's considered too slow to keep up and is dropped.
+const clientSendBuffer = 16
+
+// broadcastRequest asks the hub to deliver config to every client subscribed
+// to pageID.
+type broadcastRequest struct {
+	pageID string
+	config UIConfig
+}
+
+// initialSnapshotRequest asks the hub to deliver a newly-registered client's
+// first config. Sending it through the hub, rather than writing
+// client.lastConfig from the connection goroutine that created it, keeps
+// every access to that field on the hub goroutine alongside deliver's.
+type initialSnapshotRequest struct {
+	client *Client
+	config UIConfig
+}
+
+// Hub owns the set of connected clients and serializes registration,
+// unregistration, and broadcast delivery through its run loop, so no
+// goroutine ever holds a lock while writing to a client's socket. A slow
+// client's conn.Write blocking can therefore never stall delivery to every
+// other client, and each connection has exactly one writer: its writePump.
+type Hub struct {
+	clients map[*Client]bool
+
+	register    chan *Client
+	unregister  chan *Client
+	broadcast   chan broadcastRequest
+	snapshot    chan initialSnapshotRequest
+	resync      chan *Client
+	legacyReset chan string
+}
+
+// newHub creates a Hub and starts its run loop.
+func newHub() *Hub {
+	h := &Hub{
+		clients:     make(map[*Client]bool),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		broadcast:   make(chan broadcastRequest),
+		snapshot:    make(chan initialSnapshotRequest),
+		resync:      make(chan *Client),
+		legacyReset: make(chan string),
+	}
+	go h.run()
+	return h
+}
+
+// run is the hub's single event loop goroutine; h.clients, and every
+// client's lastConfig/legacy send-state, are only ever touched here, so
+// none of it needs a lock of its own.
+func (h *Hub) run() {
+	for {
+		select {
+		case client := <-h.register:
+			h.clients[client] = true
+			serverMetrics.ClientConnected(client.pageID)
+			metrics.CheckFDSoftLimit(int64(len(h.clients)))
+
+		case client := <-h.unregister:
+			h.drop(client)
+
+		case req := <-h.broadcast:
+			h.deliver(req)
+
+		case req := <-h.snapshot:
+			h.sendInitial(req)
+
+		case client := <-h.resync:
+			client.lastConfig = nil
+
+		case pageID := <-h.legacyReset:
+			h.resetLegacy(pageID)
+		}
+	}
+}
+
+// sendInitial delivers a newly-registered client's first config. It must run
+// on the hub goroutine: it touches client.lastConfig or client.legacySent,
+// the same fields deliver's calls into sendConfigDelta/deliverLegacy touch.
+func (h *Hub) sendInitial(req initialSnapshotRequest) {
+	if _, ok := h.clients[req.client]; !ok {
+		return
+	}
+	if req.client.legacy {
+		h.deliverLegacy(req.client, legacyStateFromConfig(req.config))
+		return
+	}
+	seq := pageSeq.Next(req.client.pageID)
+	if err := sendConfigDelta(req.client, req.config, seq); err != nil {
+		log.Printf("Websocket error: %v", err)
+		h.drop(req.client)
+	}
+}
+
+// deliver sends config to every client subscribed to req.pageID, dropping
+// any client whose send buffer is full or whose connection has gone bad.
+// legacyState is decoded once per broadcast and reused across every legacy
+// client on the page; each client still only receives the slice of chat
+// history it hasn't seen yet, tracked via its own legacySent count.
+func (h *Hub) deliver(req broadcastRequest) {
+	page, pageErr := pageStore.GetPage(req.pageID)
+	pageExists := pageErr == nil
+
+	legacyState := legacyStateFromConfig(req.config)
+	seq := pageSeq.Next(req.pageID)
+
+	for client := range h.clients {
+		if client.pageID != req.pageID {
+			continue
+		}
+		if pageExists && !page.ACL.Allows(client.userID) {
+			continue
+		}
+
+		if client.legacy {
+			h.deliverLegacy(client, legacyState)
+			continue
+		}
+		if err := sendConfigDelta(client, req.config, seq); err != nil {
+			log.Printf("Websocket error: %v", err)
+			h.drop(client)
+		}
+	}
+}
+
+// deliverLegacy sends client only the legacy envelopes it hasn't already
+// received — a "theme/update" plus any chat messages past its legacySent
+// count — and advances that count, rather than replaying the entire chat
+// history translated into envelopes on every broadcast.
+func (h *Hub) deliverLegacy(client *Client, state legacy.UIState) {
+	envelopes, sent := legacy.ToClientEnvelopesSince(state, client.legacySent)
+	for _, env := range envelopes {
+		if err := client.writeJSON(env); err != nil {
+			log.Printf("Websocket error: %v", err)
+			h.drop(client)
+			return
+		}
+	}
+	client.legacySent = sent
+}
+
+// resetLegacy tells every legacy client on pageID that the page was reset,
+// via legacy.ResetEnvelope, and clears their legacySent count so the
+// "theme/update"/"chat/message" envelopes from the broadcast that follows a
+// reset replay the (now empty) history from the start rather than being
+// diffed against counts from before the reset.
+func (h *Hub) resetLegacy(pageID string) {
+	for client := range h.clients {
+		if client.pageID != pageID || !client.legacy {
+			continue
+		}
+		if err := client.writeJSON(legacy.ResetEnvelope()); err != nil {
+			log.Printf("Websocket error: %v", err)
+			h.drop(client)
+			continue
+		}
+		client.legacySent = 0
+	}
+}
+
+// drop removes client from the hub and closes its send channel, signaling
+// its writePump to close the connection and exit. Safe to call more than
+// once for the same client.
+func (h *Hub) drop(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	close(client.send)
+	serverMetrics.ClientDisconnected(client.pageID)
+}
+
+// writePump is the only goroutine that ever writes to client.conn: it drains
+// client.send and owns the heartbeat ping ticker, so no write-side locking
+// is needed. It returns (and the caller should close the connection) once
+// send is closed by the hub or a write fails.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case raw, ok := <-c.send:
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, raw); err != nil {
+				return
+			}
+			serverMetrics.AddBytesWritten(len(raw))
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Aryan181/server-ui/backend/delta"
+	"github.com/Aryan181/server-ui/backend/store"
+)
+
+// newTestClient builds a Client for hub tests and registers it with the
+// shared package-level hub, arranging to unregister it again once the test
+// ends so it doesn't linger in hub.clients and leak into a later test that
+// happens to reuse its pageID.
+func newTestClient(t *testing.T, pageID, userID string) *Client {
+	t.Helper()
+	client := &Client{
+		pageID: pageID,
+		userID: userID,
+		send:   make(chan []byte, clientSendBuffer),
+	}
+	hub.register <- client
+	t.Cleanup(func() { hub.unregister <- client })
+	return client
+}
+
+func drainFrame(t *testing.T, c *Client, timeout time.Duration) []byte {
+	t.Helper()
+	select {
+	case raw, ok := <-c.send:
+		if !ok {
+			t.Fatal("client send channel closed unexpectedly")
+		}
+		return raw
+	case <-time.After(timeout):
+		t.Fatal("timed out waiting for a frame")
+		return nil
+	}
+}
+
+// TestHubInitialSnapshotThenPatch exercises the path review comment (b)
+// fixed: the initial config for a newly-registered client is delivered
+// through hub.snapshot, not by the connection goroutine mutating
+// client.lastConfig directly, so it must arrive as a delta.Snapshot and
+// leave the client ready to receive delta.Patch on the next broadcast.
+func TestHubInitialSnapshotThenPatch(t *testing.T) {
+	pageStore = store.NewMemoryStore()
+
+	client := newTestClient(t, "page-1", "user-1")
+
+	hub.snapshot <- initialSnapshotRequest{client: client, config: UIConfig{Layout: "light"}}
+
+	var snap delta.Snapshot
+	if err := json.Unmarshal(drainFrame(t, client, time.Second), &snap); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if snap.Type != "snapshot" {
+		t.Fatalf("expected a snapshot for a client's first config, got %q", snap.Type)
+	}
+
+	hub.broadcast <- broadcastRequest{pageID: "page-1", config: UIConfig{Layout: "dark"}}
+
+	var patch delta.Patch
+	if err := json.Unmarshal(drainFrame(t, client, time.Second), &patch); err != nil {
+		t.Fatalf("decode patch: %v", err)
+	}
+	if patch.Type != "patch" {
+		t.Fatalf("expected a patch once the client has a lastConfig, got %q", patch.Type)
+	}
+}
+
+// TestHubResyncForcesSnapshot covers the hub.resync channel: once a client
+// has a lastConfig, a resync should drop it so the next broadcast sends a
+// full delta.Snapshot again instead of a delta.Patch.
+func TestHubResyncForcesSnapshot(t *testing.T) {
+	pageStore = store.NewMemoryStore()
+
+	client := newTestClient(t, "page-4", "user-1")
+
+	hub.snapshot <- initialSnapshotRequest{client: client, config: UIConfig{Layout: "light"}}
+	drainFrame(t, client, time.Second) // initial snapshot
+
+	hub.broadcast <- broadcastRequest{pageID: "page-4", config: UIConfig{Layout: "dark"}}
+	var patch delta.Patch
+	if err := json.Unmarshal(drainFrame(t, client, time.Second), &patch); err != nil {
+		t.Fatalf("decode patch: %v", err)
+	}
+	if patch.Type != "patch" {
+		t.Fatalf("expected a patch before resync, got %q", patch.Type)
+	}
+
+	hub.resync <- client
+
+	hub.broadcast <- broadcastRequest{pageID: "page-4", config: UIConfig{Layout: "darker"}}
+	var snap delta.Snapshot
+	if err := json.Unmarshal(drainFrame(t, client, time.Second), &snap); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if snap.Type != "snapshot" {
+		t.Fatalf("expected a full snapshot after resync, got %q", snap.Type)
+	}
+}
+
+// TestHubBroadcastRespectsACL checks that deliver skips a client whose
+// userID isn't allowed on the page, rather than leaking the update to it.
+func TestHubBroadcastRespectsACL(t *testing.T) {
+	pageStore = store.NewMemoryStore()
+	if err := pageStore.PutPage("page-2", store.Page{
+		PageID: "page-2",
+		ACL:    store.ACL{Owner: "owner"},
+	}); err != nil {
+		t.Fatalf("PutPage: %v", err)
+	}
+
+	allowed := newTestClient(t, "page-2", "owner")
+	blocked := newTestClient(t, "page-2", "stranger")
+
+	hub.broadcast <- broadcastRequest{pageID: "page-2", config: UIConfig{Layout: "light"}}
+
+	drainFrame(t, allowed, time.Second)
+
+	select {
+	case raw := <-blocked.send:
+		t.Fatalf("expected no frame for a client outside the page's ACL, got %s", raw)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHubLegacyClientOnlySeesNewMessages covers review comment (f): a
+// legacy client's chat history replay should only grow by the messages it
+// hasn't seen yet, not resend the whole history on every broadcast.
+func TestHubLegacyClientOnlySeesNewMessages(t *testing.T) {
+	pageStore = store.NewMemoryStore()
+
+	client := newTestClient(t, "page-3", "user-1")
+	client.legacy = true
+
+	configWithOneMessage := UIConfig{
+		Components: []Component{{
+			Type:       "chat-messages",
+			Properties: map[string]string{"messages": `[{"id":"1","content":"hi"}]`},
+		}},
+	}
+	hub.snapshot <- initialSnapshotRequest{client: client, config: configWithOneMessage}
+
+	// theme/update, then one chat/message envelope.
+	drainFrame(t, client, time.Second)
+	drainFrame(t, client, time.Second)
+
+	configWithTwoMessages := UIConfig{
+		Components: []Component{{
+			Type:       "chat-messages",
+			Properties: map[string]string{"messages": `[{"id":"1","content":"hi"},{"id":"2","content":"there"}]`},
+		}},
+	}
+	hub.broadcast <- broadcastRequest{pageID: "page-3", config: configWithTwoMessages}
+
+	// theme/update, then only the new chat/message envelope.
+	drainFrame(t, client, time.Second)
+	drainFrame(t, client, time.Second)
+
+	// client.legacySent is only safe to read on the hub goroutine; draining
+	// client.send only guarantees the corresponding writeJSON call happened,
+	// not that deliverLegacy's later assignment to legacySent has run. A
+	// send on hub.broadcast only completes once the hub is back at its
+	// select awaiting the next request, which forces that assignment to
+	// have already happened.
+	hub.broadcast <- broadcastRequest{pageID: "unrelated-page", config: UIConfig{}}
+	if client.legacySent != 2 {
+		t.Fatalf("expected legacySent to advance to 2, got %d", client.legacySent)
+	}
+
+	select {
+	case raw := <-client.send:
+		t.Fatalf("expected no further frames after the new message, got %s", raw)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
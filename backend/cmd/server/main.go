@@ -2,19 +2,26 @@
 Package main provides a WebSocket-based configuration management server.
 
 Key Features:
-- Real-time configuration updates via WebSocket
+- Real-time configuration updates via WebSocket, sent as JSON Patch deltas
+  after the initial snapshot to keep broadcasts small
 - Multi-page support with individual configurations
 - Theme and layout management
-- Chat message handling
+- Chat message handling, including inbound chat commands over WebSocket
 - CORS support
 - Static file serving
+- Operational metrics: active clients per page, bytes written, message
+  throughput, and rejected connections, with a per-page connection limit
 
 API Endpoints:
 - GET  /api/pages           - List all available pages
 - GET  /api/pages/{pageId}  - Get configuration for specific page
 - POST /api/pages/{pageId}  - Update configuration for specific page
 - POST /api/reset           - Reset configuration to defaults
+- POST /api/pages/{pageId}/webhook/{token} - Inbound webhook bridge; appends a chat message
+- GET  /api/metrics         - JSON snapshot of operational metrics
 - GET  /ws                  - WebSocket endpoint for real-time updates
+- GET  /ws/v1               - Legacy envelope-protocol WebSocket endpoint, for older frontends
+- GET  /metrics             - Prometheus-format operational metrics
 
 Usage:
 
@@ -26,7 +33,9 @@ Usage:
 Security Notes:
   - This implementation allows all CORS origins
   - WebSocket connections accept all origins
-  - No authentication is implemented
+  - Requests to /api/pages/*, /api/reset, and /ws require a session token
+    obtained from POST /api/login (see auth.go), except the inbound webhook
+    route, which is authenticated by the token in its URL instead
   - Intended for development/demo use only
 */
 
@@ -34,40 +43,63 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
-)
 
-// SharedConfig represents the core configuration shared across a chat instance.
-// It contains all necessary information for rendering the chat interface and managing messages.
-type SharedConfig struct {
-	DisplayMessage string    `json:"message"`     // Message to be displayed in the chat header
-	CurrentColor   string    `json:"color"`       // Current theme color
-	Theme          string    `json:"theme"`       // UI theme (light/dark)
-	ChatPartner    ChatUser  `json:"chatPartner"` // Information about the chat partner
-	Messages       []Message `json:"messages"`    // Array of chat messages
-}
+	"github.com/Aryan181/server-ui/backend/delta"
+	"github.com/Aryan181/server-ui/backend/legacy"
+	"github.com/Aryan181/server-ui/backend/metrics"
+	"github.com/Aryan181/server-ui/backend/store"
+)
 
-// ChatUser represents a user in the chat system with their basic information.
-type ChatUser struct {
-	Name   string `json:"name"`   // Display name of the user
-	Status string `json:"status"` // Online status (Online/Offline/Away)
-	Avatar string `json:"avatar"` // URL to user's avatar image
+// pageSeq hands out per-page sequence numbers for delta.Patch/delta.Snapshot
+// messages, so clients can detect a gap and resync.
+var pageSeq = delta.NewSequencer()
+
+// serverMetrics tracks connection counts, throughput, and rejections for
+// GET /api/metrics and GET /metrics.
+var serverMetrics = metrics.New()
+
+// defaultMaxClientsPerPage caps how many WebSocket clients may subscribe to
+// a single page when MAX_CLIENTS_PER_PAGE isn't set.
+const defaultMaxClientsPerPage = 100
+
+// maxClientsPerPage returns the configured per-page WebSocket client limit,
+// falling back to defaultMaxClientsPerPage if MAX_CLIENTS_PER_PAGE is unset
+// or invalid.
+func maxClientsPerPage() int64 {
+	raw := os.Getenv("MAX_CLIENTS_PER_PAGE")
+	if raw == "" {
+		return defaultMaxClientsPerPage
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid MAX_CLIENTS_PER_PAGE %q, using default of %d", raw, defaultMaxClientsPerPage)
+		return defaultMaxClientsPerPage
+	}
+	return n
 }
 
-// Message represents a single chat message in the system.
-type Message struct {
-	ID        string    `json:"id"`        // Unique identifier for the message
-	Content   string    `json:"content"`   // Message content
-	Sender    string    `json:"sender"`    // Name of message sender
-	Timestamp time.Time `json:"timestamp"` // Time when message was sent
-}
+// SharedConfig, ChatUser, Message, PageConfig, and PageACL are aliases onto
+// the store package's domain types, kept under their original names so the
+// rest of this file (and its JSON wire format) didn't need to change when
+// persistence was introduced.
+type (
+	SharedConfig = store.SharedConfig
+	ChatUser     = store.ChatUser
+	Message      = store.Message
+	PageConfig   = store.Page
+	PageACL      = store.ACL
+)
 
 // UIConfig defines the complete UI configuration structure sent to clients.
 type UIConfig struct {
@@ -92,17 +124,34 @@ type ThemeConfig struct {
 	FontSize       string `json:"fontSize"`       // Base font size
 }
 
-// PageConfig stores configuration for individual chat pages.
-type PageConfig struct {
-	PageID      string       `json:"pageId"`      // Unique page identifier
-	DisplayName string       `json:"displayName"` // Human-readable page name
-	Config      SharedConfig `json:"config"`      // Page-specific configuration
-}
-
 // Client represents a connected WebSocket client.
 type Client struct {
-	conn   *websocket.Conn // WebSocket connection
-	pageID string          // ID of the page client is subscribed to
+	conn       *websocket.Conn // WebSocket connection
+	pageID     string          // ID of the page client is subscribed to
+	userID     string          // ID of the authenticated user for this connection
+	legacy     bool            // true if connected via /ws/v1 and expects the legacy envelope protocol
+	send       chan []byte     // outbound frames for this client's writePump; closed by the hub on unregister
+	limiter    *tokenBucket    // per-connection inbound rate limiter
+	lastConfig *UIConfig       // last config broadcast to this client, for delta diffing; nil forces a snapshot
+	legacySent int             // number of chat messages already delivered to this legacy client
+}
+
+// writeJSON marshals v and queues it on the client's send buffer for its
+// writePump to deliver. It returns an error, rather than blocking, if the
+// buffer is full. The hub treats that as a dead client and drops it; a
+// client's own read loop replying to a ping only logs the error.
+func (c *Client) writeJSON(v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.send <- raw:
+		return nil
+	default:
+		return fmt.Errorf("client send buffer full")
+	}
 }
 
 // Global variables for managing state
@@ -113,30 +162,124 @@ var (
 		},
 	}
 
-	sharedConfig SharedConfig                  // Global shared configuration
-	configLock   sync.RWMutex                  // Mutex for sharedConfig access
-	clients      = make(map[*Client]bool)      // Connected clients
-	clientsLock  sync.RWMutex                  // Mutex for clients map access
-	pages        = make(map[string]PageConfig) // Page configurations
-	pagesLock    sync.RWMutex                  // Mutex for pages map access
+	sharedConfig SharedConfig // Global shared configuration
+	configLock   sync.RWMutex // Mutex for sharedConfig access
+
+	hub = newHub() // Owns connected clients and serializes broadcast delivery
+
+	pageStore store.Store // Persistence backend for PageConfig and messages
 )
 
-// broadcastToClients sends the updated UIConfig to all clients subscribed to a specific page.
-// If pageID is empty, broadcasts to all clients.
+// globalPageID is the store key under which the legacy, page-less
+// sharedConfig is persisted.
+const globalPageID = ""
+
+// defaultPage returns the default PageConfig new pages are initialized
+// with before any fields are overridden by the caller.
+func defaultPage(pageID string) PageConfig {
+	return PageConfig{
+		PageID: pageID,
+		Config: SharedConfig{
+			DisplayMessage: "Welcome to Chat",
+			CurrentColor:   "#ffffff",
+			Theme:          "light",
+		},
+	}
+}
+
+// newStoreFromEnv constructs the persistence backend selected by the
+// STORE_BACKEND environment variable ("memory" (default), "bolt", or
+// "sqlite"). STORE_DSN gives the file path / DSN for the latter two.
+func newStoreFromEnv() store.Store {
+	backend := os.Getenv("STORE_BACKEND")
+	dsn := os.Getenv("STORE_DSN")
+
+	switch backend {
+	case "bolt":
+		if dsn == "" {
+			dsn = "pages.bolt"
+		}
+		s, err := store.NewBoltStore(dsn)
+		if err != nil {
+			log.Fatalf("failed to open bolt store: %v", err)
+		}
+		return s
+	case "sqlite":
+		if dsn == "" {
+			dsn = "pages.sqlite"
+		}
+		s, err := store.NewSQLiteStore(dsn)
+		if err != nil {
+			log.Fatalf("failed to open sqlite store: %v", err)
+		}
+		return s
+	default:
+		return store.NewMemoryStore()
+	}
+}
+
+// broadcastToClients asks the hub to deliver the updated UIConfig to all
+// clients subscribed to a specific page. An empty pageID reaches only
+// clients whose pageID is empty; /ws and /ws/v1 refuse such connections, so
+// no client receives it. Clients that are no longer authorized to view the page
+// (e.g. its ACL changed since they connected) are skipped by the hub. This
+// only enqueues work onto the hub's channel, so it never blocks on a slow
+// client's socket.
 func broadcastToClients(config UIConfig, pageID string) {
-	clientsLock.Lock()
-	defer clientsLock.Unlock()
-
-	for client := range clients {
-		if client.pageID == pageID {
-			err := client.conn.WriteJSON(config)
-			if err != nil {
-				log.Printf("Websocket error: %v", err)
-				client.conn.Close()
-				delete(clients, client)
-			}
+	hub.broadcast <- broadcastRequest{pageID: pageID, config: config}
+}
+
+// sendConfigDelta pushes config to client as a JSON Patch against its last
+// known state, or a full delta.Snapshot if it has none (first send, or a
+// requested resync). seq is the sequence number for this broadcast round,
+// shared by every client on the page so they can line up snapshots and
+// patches against each other. On success it updates client.lastConfig so
+// the next broadcast can diff against it.
+func sendConfigDelta(client *Client, config UIConfig, seq uint64) error {
+	if client.lastConfig == nil {
+		if err := client.writeJSON(delta.Snapshot{Type: "snapshot", Seq: seq, Data: config}); err != nil {
+			return err
+		}
+		client.lastConfig = &config
+		return nil
+	}
+
+	ops, err := delta.Diff(*client.lastConfig, config)
+	if err != nil {
+		return fmt.Errorf("compute delta: %w", err)
+	}
+	if err := client.writeJSON(delta.Patch{Type: "patch", Seq: seq, Ops: ops}); err != nil {
+		return err
+	}
+	client.lastConfig = &config
+	return nil
+}
+
+// legacyStateFromConfig extracts the subset of a UIConfig the legacy bridge
+// needs in order to build client-bound events.
+func legacyStateFromConfig(config UIConfig) legacy.UIState {
+	state := legacy.UIState{
+		Theme:        config.Layout,
+		PrimaryColor: config.Theme.PrimaryColor,
+	}
+	for _, c := range config.Components {
+		if c.Type != "chat-messages" {
+			continue
+		}
+		var msgs []Message
+		if err := json.Unmarshal([]byte(c.Properties["messages"]), &msgs); err != nil {
+			continue
+		}
+		for _, m := range msgs {
+			state.Messages = append(state.Messages, legacy.ChatMessagePayload{
+				ID:        m.ID,
+				Content:   m.Content,
+				Sender:    m.Sender,
+				Timestamp: m.Timestamp,
+			})
 		}
 	}
+	return state
 }
 
 // handleWebSocket manages WebSocket connections for real-time updates.
@@ -150,6 +293,30 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	token := extractToken(r)
+	if token == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	session, err := parseSession(token)
+	if err != nil {
+		http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+		return
+	}
+
+	page, pageErr := pageStore.GetPage(pageID)
+	pageExists := pageErr == nil
+	if pageExists && !page.ACL.Allows(session.UserID) {
+		http.Error(w, "Not authorized for this page", http.StatusForbidden)
+		return
+	}
+
+	if serverMetrics.ClientsForPage(pageID) >= maxClientsPerPage() {
+		serverMetrics.ConnectionRejected()
+		http.Error(w, "Too many clients connected to this page", http.StatusServiceUnavailable)
+		return
+	}
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Websocket upgrade error: %v", err)
@@ -157,17 +324,19 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
-		conn:   ws,
-		pageID: pageID,
+		conn:    ws,
+		pageID:  pageID,
+		userID:  session.UserID,
+		send:    make(chan []byte, clientSendBuffer),
+		limiter: newTokenBucket(inboundBurst, inboundRefillPerSec),
 	}
 
-	clientsLock.Lock()
-	clients[client] = true
-	clientsLock.Unlock()
+	armReadDeadline(client)
+	hub.register <- client
+	go client.writePump()
 
 	// Send initial config for the requested page
-	pagesLock.RLock()
-	if page, exists := pages[pageID]; exists {
+	if pageExists {
 		log.Printf("Found page config for %s: %+v", pageID, page)
 		// Create UI config directly from page config
 		newConfig := UIConfig{
@@ -199,21 +368,29 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			},
 		}
 		log.Printf("Sending initial config: %+v", newConfig)
-		if err := ws.WriteJSON(newConfig); err != nil {
-			log.Printf("Error sending initial config: %v", err)
-		}
+		// Routed through the hub rather than written here: client.lastConfig
+		// is also read and written by the hub's own deliver/sendConfigDelta,
+		// so only the hub goroutine may ever touch it.
+		hub.snapshot <- initialSnapshotRequest{client: client, config: newConfig}
 	}
-	pagesLock.RUnlock()
 
-	// Keep connection alive and clean up on disconnect
+	// Read inbound commands until the connection closes, rate-limiting and
+	// dispatching each one in turn.
 	for {
-		_, _, err := ws.ReadMessage()
+		_, raw, err := ws.ReadMessage()
 		if err != nil {
-			clientsLock.Lock()
-			delete(clients, client)
-			clientsLock.Unlock()
+			hub.unregister <- client
 			break
 		}
+
+		if !client.limiter.Allow() {
+			log.Printf("Rate limit exceeded for client on page %s; dropping message", pageID)
+			continue
+		}
+
+		if err := handleInboundMessage(client, raw); err != nil {
+			log.Printf("Error handling inbound message on page %s: %v", pageID, err)
+		}
 	}
 }
 
@@ -316,6 +493,10 @@ func resetUIConfig(w http.ResponseWriter, r *http.Request) {
 	}
 	configLock.Unlock()
 
+	if err := pageStore.ResetPage(globalPageID, defaultPage(globalPageID)); err != nil {
+		log.Printf("Error resetting global page in store: %v", err)
+	}
+
 	// Build and broadcast new config
 	newConfig := buildUIConfig(sharedConfig.DisplayMessage, sharedConfig.CurrentColor, sharedConfig.Theme)
 	broadcastToClients(newConfig, "")
@@ -326,6 +507,9 @@ func resetUIConfig(w http.ResponseWriter, r *http.Request) {
 
 // updatePageConfig handles updates to page-specific configurations.
 // It validates the update, stores it, and broadcasts changes to relevant clients.
+// Messages carried in the request body are appended to the stored history
+// incrementally rather than overwriting it, so the store is the source of
+// truth for a page's full message log.
 func updatePageConfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	pageID := vars["pageId"]
@@ -341,7 +525,37 @@ func updatePageConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pagesLock.Lock()
+	for _, hookURL := range update.OutgoingWebhooks {
+		if err := validateOutgoingWebhookURL(hookURL); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid outgoing webhook URL %q: %v", hookURL, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID := userIDFromRequest(r)
+
+	existing, err := pageStore.GetPage(pageID)
+	pageExists := err == nil
+	if pageExists {
+		if !existing.ACL.Allows(userID) {
+			http.Error(w, "Not authorized for this page", http.StatusForbidden)
+			return
+		}
+		// Preserve ownership; ACL changes go through a dedicated endpoint in a
+		// future iteration rather than being overwritten by every config POST.
+		update.ACL = existing.ACL
+		if update.InboundToken == "" {
+			update.InboundToken = existing.InboundToken
+		}
+		if update.WebhookSecret == "" {
+			update.WebhookSecret = existing.WebhookSecret
+		}
+	} else {
+		update.ACL = PageACL{Owner: userID}
+		update.InboundToken = randomHexToken(16)
+		update.WebhookSecret = randomHexToken(32)
+	}
+
 	// Ensure all required fields are set
 	if update.Config.DisplayMessage == "" {
 		update.Config.DisplayMessage = "Welcome to Chat"
@@ -352,8 +566,36 @@ func updatePageConfig(w http.ResponseWriter, r *http.Request) {
 	if update.Config.Theme == "" {
 		update.Config.Theme = "light"
 	}
-	pages[pageID] = update
-	pagesLock.Unlock()
+
+	// Treat incoming Messages as new additions, not a replacement of the
+	// stored history; persist them one at a time via AppendMessage.
+	newMessages := update.Config.Messages
+	if pageExists {
+		update.Config.Messages = existing.Config.Messages
+	} else {
+		update.Config.Messages = nil
+	}
+
+	if err := pageStore.PutPage(pageID, update); err != nil {
+		http.Error(w, "Failed to save page", http.StatusInternalServerError)
+		return
+	}
+	for _, msg := range newMessages {
+		if err := pageStore.AppendMessage(pageID, msg); err != nil {
+			log.Printf("Error appending message to page %s: %v", pageID, err)
+			http.Error(w, fmt.Sprintf("Failed to save message %q", msg.ID), http.StatusInternalServerError)
+			return
+		}
+		serverMetrics.MessageAppended()
+		notifyWebhooks(pageID, "message", msg)
+	}
+
+	update, err = pageStore.GetPage(pageID)
+	if err != nil {
+		http.Error(w, "Failed to load saved page", http.StatusInternalServerError)
+		return
+	}
+	notifyWebhooks(pageID, "config", update)
 
 	// Build and broadcast new config for this page
 	newConfig := UIConfig{
@@ -394,31 +636,46 @@ func updatePageConfig(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// listPages returns a JSON array of all available pages and their display names.
+// listPages returns a JSON array of the pages the requesting user is
+// authorized to see, along with their display names.
 func listPages(w http.ResponseWriter, r *http.Request) {
-	pagesLock.RLock()
+	userID := userIDFromRequest(r)
+
+	allPages, err := pageStore.ListPages()
+	if err != nil {
+		http.Error(w, "Failed to list pages", http.StatusInternalServerError)
+		return
+	}
+
 	pageList := make([]map[string]string, 0)
-	for _, page := range pages {
+	for _, page := range allPages {
+		if !page.ACL.Allows(userID) {
+			continue
+		}
 		pageList = append(pageList, map[string]string{
 			"pageId":      page.PageID,
 			"displayName": page.DisplayName,
 		})
 	}
-	pagesLock.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(pageList)
 }
 
 // getPageConfig retrieves and returns the configuration for a specific page.
-// Returns 404 if the page doesn't exist.
+// Returns 404 if the page doesn't exist, 403 if the requesting user isn't
+// authorized for it.
 func getPageConfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	pageID := vars["pageId"]
 
-	pagesLock.RLock()
-	page, exists := pages[pageID]
-	pagesLock.RUnlock()
+	page, err := pageStore.GetPage(pageID)
+	exists := err == nil
+
+	if exists && !page.ACL.Allows(userIDFromRequest(r)) {
+		http.Error(w, "Not authorized for this page", http.StatusForbidden)
+		return
+	}
 
 	if !exists {
 		http.Error(w, "Page not found", http.StatusNotFound)
@@ -432,6 +689,16 @@ func getPageConfig(w http.ResponseWriter, r *http.Request) {
 // main initializes and starts the HTTP server with WebSocket support.
 // It sets up routes, middleware, and begins listening for connections.
 func main() {
+	pageStore = newStoreFromEnv()
+
+	// Load any previously persisted global config so a restart doesn't wipe
+	// the chat history kept under sharedConfig.
+	if global, err := pageStore.GetPage(globalPageID); err == nil {
+		configLock.Lock()
+		sharedConfig = global.Config
+		configLock.Unlock()
+	}
+
 	r := mux.NewRouter()
 
 	// CORS middleware first
@@ -451,13 +718,24 @@ func main() {
 
 	// API subrouter
 	api := r.PathPrefix("/api").Subrouter()
-	api.HandleFunc("/pages", listPages).Methods("GET")
-	api.HandleFunc("/pages/{pageId}", updatePageConfig).Methods("POST")
-	api.HandleFunc("/pages/{pageId}", getPageConfig).Methods("GET")
-	api.HandleFunc("/reset", resetUIConfig).Methods("POST")
-
-	// WebSocket route
+	api.HandleFunc("/login", handleLogin).Methods("POST")
+	api.HandleFunc("/users", requireAuth(handleCreateUser)).Methods("POST")
+	api.HandleFunc("/pages", requireAuth(listPages)).Methods("GET")
+	api.HandleFunc("/pages/{pageId}", requireAuth(updatePageConfig)).Methods("POST")
+	api.HandleFunc("/pages/{pageId}", requireAuth(getPageConfig)).Methods("GET")
+	api.HandleFunc("/reset", requireAuth(resetUIConfig)).Methods("POST")
+	api.HandleFunc("/pages/{pageId}/webhook/{token}", handleInboundWebhook).Methods("POST")
+	api.HandleFunc("/metrics", requireAuth(handleMetrics)).Methods("GET")
+
+	// WebSocket routes (authenticated inside the handlers themselves, since
+	// the token arrives via query param or Sec-WebSocket-Protocol rather
+	// than a header)
 	r.HandleFunc("/ws", handleWebSocket)
+	r.HandleFunc("/ws/v1", handleLegacyWebSocket)
+
+	// Unauthenticated Prometheus scrape endpoint, outside /api like the
+	// WebSocket routes above.
+	r.HandleFunc("/metrics", handlePrometheusMetrics).Methods("GET")
 
 	// Static files last
 	fs := http.FileServer(http.Dir("frontend/dist"))
@@ -468,11 +746,17 @@ func main() {
 
 	log.Println("Server starting on :8080...")
 	log.Printf("Routes registered:")
+	log.Printf("- POST /api/login")
+	log.Printf("- POST /api/users")
 	log.Printf("- GET /api/pages")
 	log.Printf("- POST /api/pages/{pageId}")
 	log.Printf("- GET /api/pages/{pageId}")
 	log.Printf("- POST /api/reset")
+	log.Printf("- POST /api/pages/{pageId}/webhook/{token}")
+	log.Printf("- GET /api/metrics")
 	log.Printf("- GET /ws")
+	log.Printf("- GET /ws/v1 (legacy envelope protocol)")
+	log.Printf("- GET /metrics (Prometheus)")
 
 	log.Fatal(http.ListenAndServe(":8080", handler))
 }
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleMetrics serves GET /api/metrics: a JSON snapshot of the server's
+// operational counters for dashboards or ad hoc inspection.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(serverMetrics.Snapshot())
+}
+
+// handlePrometheusMetrics serves GET /metrics in Prometheus text exposition
+// format, so the server can be scraped directly without a sidecar exporter.
+func handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	serverMetrics.WritePrometheus(w)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Aryan181/server-ui/backend/webhook"
+)
+
+// allowPrivateOutgoingWebhooks disables validateOutgoingWebhookURL's
+// private/loopback check, for local development against services that only
+// exist on a private network. Leave unset in any real deployment.
+var allowPrivateOutgoingWebhooks = os.Getenv("ALLOW_PRIVATE_WEBHOOKS") == "true"
+
+// validateOutgoingWebhookURL rejects destinations that would let a page
+// owner turn this server's outgoing webhook dispatcher into an SSRF
+// primitive: only plain http/https URLs with a host are accepted, and
+// loopback/link-local/private addresses (e.g. cloud metadata endpoints)
+// are rejected unless allowPrivateOutgoingWebhooks is set.
+func validateOutgoingWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL must use http or https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL must include a host")
+	}
+	if allowPrivateOutgoingWebhooks {
+		return nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("could not resolve host: %w", err)
+	}
+	for _, ip := range ips {
+		if !webhook.IsPublicIP(ip) {
+			return fmt.Errorf("URL resolves to a private or loopback address")
+		}
+	}
+	return nil
+}
+
+// webhookDispatcher delivers signed outgoing webhook events in the
+// background. A small fixed worker pool is enough for this server's scale.
+var webhookDispatcher = webhook.NewDispatcher(4)
+
+// notifyWebhooks dispatches eventType (e.g. "message", "config") for pageID
+// to every outgoing webhook URL configured on that page. It's a no-op if the
+// page doesn't exist or has none configured.
+func notifyWebhooks(pageID, eventType string, payload interface{}) {
+	page, err := pageStore.GetPage(pageID)
+	if err != nil || len(page.OutgoingWebhooks) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("webhook: failed to encode %s payload for page %s: %v", eventType, pageID, err)
+		return
+	}
+
+	event := webhook.Event{
+		PageID:    pageID,
+		Type:      eventType,
+		Payload:   raw,
+		Timestamp: time.Now(),
+	}
+	for _, url := range page.OutgoingWebhooks {
+		webhookDispatcher.Send(url, page.WebhookSecret, event)
+	}
+}
+
+// inboundWebhookPayload is the body accepted by the inbound webhook endpoint,
+// modeled on a simple chat-bridge message.
+type inboundWebhookPayload struct {
+	Username string `json:"username"`
+	Text     string `json:"text"`
+	Avatar   string `json:"avatar"`
+}
+
+// handleInboundWebhook accepts POST /api/pages/{pageId}/webhook/{token},
+// appending the payload as a chat Message and broadcasting it. The token in
+// the URL (rather than a session) authenticates the request, so external
+// systems can post without logging in.
+func handleInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	pageID := vars["pageId"]
+	token := vars["token"]
+
+	page, err := pageStore.GetPage(pageID)
+	if err != nil {
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	}
+	if page.InboundToken == "" || subtle.ConstantTimeCompare([]byte(page.InboundToken), []byte(token)) != 1 {
+		http.Error(w, "Invalid webhook token", http.StatusForbidden)
+		return
+	}
+
+	var payload inboundWebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	msg := Message{
+		ID:        time.Now().Format(time.RFC3339Nano),
+		Content:   payload.Text,
+		Sender:    payload.Username,
+		Timestamp: time.Now(),
+	}
+	if err := pageStore.AppendMessage(pageID, msg); err != nil {
+		http.Error(w, "Failed to append message", http.StatusInternalServerError)
+		return
+	}
+	serverMetrics.MessageAppended()
+
+	notifyWebhooks(pageID, "message", msg)
+	if err := broadcastPage(pageID); err != nil {
+		log.Printf("Error broadcasting after inbound webhook for page %s: %v", pageID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "received"})
+}
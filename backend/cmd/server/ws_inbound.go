@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// inboundBurst and inboundRefillPerSec bound how many inbound WebSocket
+	// messages a single connection may send: a short burst up to the
+	// capacity, refilled steadily afterwards.
+	inboundBurst        = 10
+	inboundRefillPerSec = 2
+
+	// pongWait is how long a connection may go without a pong before it's
+	// considered dead. pingPeriod must stay well under pongWait so pings
+	// land before the deadline expires.
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+)
+
+// InboundMessage is the typed envelope clients send over /ws to drive
+// server-side state, as opposed to the server-to-client UIConfig pushes.
+type InboundMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SendMessagePayload is the payload for a "send_message" inbound message.
+type SendMessagePayload struct {
+	Content string `json:"content"`
+}
+
+// SetThemePayload is the payload for a "set_theme" inbound message.
+type SetThemePayload struct {
+	Theme        string `json:"theme"`
+	PrimaryColor string `json:"primaryColor"`
+}
+
+// SetPartnerPayload is the payload for a "set_partner" inbound message.
+type SetPartnerPayload struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Avatar string `json:"avatar"`
+}
+
+// handleInboundMessage decodes and dispatches one inbound WebSocket message
+// from client, mutating the relevant page and broadcasting the result.
+func handleInboundMessage(client *Client, raw []byte) error {
+	var msg InboundMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return fmt.Errorf("malformed inbound message: %w", err)
+	}
+
+	switch msg.Type {
+	case "ping":
+		return client.writeJSON(map[string]string{"type": "pong"})
+
+	case "send_message":
+		var payload SendMessagePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("malformed send_message payload: %w", err)
+		}
+		return legacyBackend{}.SendMessage(client.pageID, client.userID, payload.Content)
+
+	case "set_theme":
+		var payload SetThemePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("malformed set_theme payload: %w", err)
+		}
+		return legacyBackend{}.SetTheme(client.pageID, payload.Theme, payload.PrimaryColor)
+
+	case "set_partner":
+		var payload SetPartnerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("malformed set_partner payload: %w", err)
+		}
+		return setPagePartner(client.pageID, payload)
+
+	case "resync":
+		// Client lost track of the patch stream (e.g. detected a sequence
+		// gap); drop its cached state so the next broadcast sends a full
+		// delta.Snapshot instead of a patch. Routed through the hub, not
+		// written here, since client.lastConfig is also read and written by
+		// the hub's own deliver/sendConfigDelta.
+		hub.resync <- client
+		return nil
+
+	default:
+		return fmt.Errorf("unknown inbound message type %q", msg.Type)
+	}
+}
+
+// setPagePartner updates the chat partner info for a page and broadcasts it.
+func setPagePartner(pageID string, payload SetPartnerPayload) error {
+	page, err := pageStore.GetPage(pageID)
+	if err != nil {
+		page = defaultPage(pageID)
+	}
+	page.Config.ChatPartner = ChatUser{
+		Name:   payload.Name,
+		Status: payload.Status,
+		Avatar: payload.Avatar,
+	}
+	if err := pageStore.PutPage(pageID, page); err != nil {
+		return err
+	}
+	notifyWebhooks(pageID, "config", page)
+	return broadcastPage(pageID)
+}
+
+// armReadDeadline arranges for client's connection to be considered dead,
+// and closed by its writePump, if it goes quiet for longer than pongWait.
+// writePump's ticker is what actually sends the pings; this just resets the
+// deadline whenever a pong comes back. Other inbound frames do not extend it.
+func armReadDeadline(client *Client) {
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+}
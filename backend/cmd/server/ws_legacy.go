@@ -0,0 +1,190 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Aryan181/server-ui/backend/legacy"
+)
+
+// legacyBackend adapts the legacy package's Backend interface onto the
+// server's page store, so old frontends speaking the envelope protocol over
+// /ws/v1 drive the same state and broadcasts as the current /ws clients.
+type legacyBackend struct{}
+
+func (legacyBackend) SendMessage(pageID, sender, content string) error {
+	page, err := pageStore.GetPage(pageID)
+	if err != nil {
+		page = defaultPage(pageID)
+	}
+	msg := Message{
+		ID:        time.Now().Format(time.RFC3339Nano),
+		Content:   content,
+		Sender:    sender,
+		Timestamp: time.Now(),
+	}
+	if err := pageStore.AppendMessage(pageID, msg); err != nil {
+		// Page didn't exist yet; create it with the message already attached.
+		page.Config.Messages = append(page.Config.Messages, msg)
+		if err := pageStore.PutPage(pageID, page); err != nil {
+			return err
+		}
+	}
+	serverMetrics.MessageAppended()
+	notifyWebhooks(pageID, "message", msg)
+	return broadcastPage(pageID)
+}
+
+func (legacyBackend) SetTheme(pageID, theme, primaryColor string) error {
+	page, err := pageStore.GetPage(pageID)
+	if err != nil {
+		page = defaultPage(pageID)
+	}
+	page.Config.Theme = theme
+	page.Config.CurrentColor = primaryColor
+	if err := pageStore.PutPage(pageID, page); err != nil {
+		return err
+	}
+	notifyWebhooks(pageID, "config", page)
+	return broadcastPage(pageID)
+}
+
+func (legacyBackend) ResetPage(pageID string) error {
+	if err := pageStore.ResetPage(pageID, defaultPage(pageID)); err != nil {
+		return err
+	}
+	// Routed through the hub, not written here: this writes directly to
+	// legacy clients' send channels and touches client.legacySent, both of
+	// which only the hub goroutine may touch.
+	hub.legacyReset <- pageID
+	return broadcastPage(pageID)
+}
+
+// broadcastPage loads pageID from the store and broadcasts its current
+// config to every subscribed client (legacy clients receive it translated
+// into envelopes by the hub's deliverLegacy).
+func broadcastPage(pageID string) error {
+	page, err := pageStore.GetPage(pageID)
+	if err != nil {
+		return err
+	}
+	broadcastToClients(UIConfig{
+		Layout:    page.Config.Theme,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+		Theme: ThemeConfig{
+			PrimaryColor:   page.Config.CurrentColor,
+			SecondaryColor: "#000000",
+			FontSize:       "16px",
+		},
+		Components: []Component{
+			{
+				Type:    "chat-header",
+				ID:      "chat-partner-info",
+				Content: page.Config.DisplayMessage,
+				Properties: map[string]string{
+					"userName":   page.Config.ChatPartner.Name,
+					"userStatus": page.Config.ChatPartner.Status,
+				},
+			},
+			{
+				Type:    "chat-messages",
+				ID:      "message-list",
+				Content: "",
+				Properties: map[string]string{
+					"messages": string(mustEncodeJSON(page.Config.Messages)),
+				},
+			},
+		},
+	}, pageID)
+	return nil
+}
+
+// handleLegacyWebSocket serves /ws/v1: the same subscription model as
+// handleWebSocket, but frames are the older {event, payload} envelope rather
+// than delta snapshots and patches, translated at the edge by the legacy
+// package.
+func handleLegacyWebSocket(w http.ResponseWriter, r *http.Request) {
+	pageID := r.URL.Query().Get("pageId")
+	if pageID == "" {
+		http.Error(w, "PageID is required", http.StatusBadRequest)
+		return
+	}
+
+	token := extractToken(r)
+	if token == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	session, err := parseSession(token)
+	if err != nil {
+		http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+		return
+	}
+
+	page, pageErr := pageStore.GetPage(pageID)
+	pageExists := pageErr == nil
+	if pageExists && !page.ACL.Allows(session.UserID) {
+		http.Error(w, "Not authorized for this page", http.StatusForbidden)
+		return
+	}
+
+	if serverMetrics.ClientsForPage(pageID) >= maxClientsPerPage() {
+		serverMetrics.ConnectionRejected()
+		http.Error(w, "Too many clients connected to this page", http.StatusServiceUnavailable)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Legacy websocket upgrade error: %v", err)
+		return
+	}
+
+	client := &Client{
+		conn:    ws,
+		pageID:  pageID,
+		userID:  session.UserID,
+		legacy:  true,
+		send:    make(chan []byte, clientSendBuffer),
+		limiter: newTokenBucket(inboundBurst, inboundRefillPerSec),
+	}
+
+	armReadDeadline(client)
+	hub.register <- client
+	go client.writePump()
+
+	if pageExists {
+		// Routed through the hub, not written here: client.legacySent is
+		// also read and written by the hub's own deliver/deliverLegacy, so
+		// only the hub goroutine may ever touch it.
+		hub.snapshot <- initialSnapshotRequest{
+			client: client,
+			config: UIConfig{
+				Layout: page.Config.Theme,
+				Theme:  ThemeConfig{PrimaryColor: page.Config.CurrentColor},
+				Components: []Component{
+					{Type: "chat-messages", Properties: map[string]string{
+						"messages": string(mustEncodeJSON(page.Config.Messages)),
+					}},
+				},
+			},
+		}
+	}
+
+	backend := legacyBackend{}
+	for {
+		_, raw, err := ws.ReadMessage()
+		if err != nil {
+			hub.unregister <- client
+			break
+		}
+		if !client.limiter.Allow() {
+			log.Printf("Rate limit exceeded for legacy client on page %s; dropping frame", pageID)
+			continue
+		}
+		if err := legacy.HandleClientFrame(backend, pageID, client.userID, raw); err != nil {
+			log.Printf("Legacy websocket frame error: %v", err)
+		}
+	}
+}
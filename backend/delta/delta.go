@@ -0,0 +1,192 @@
+// Package delta computes RFC 6902 JSON Patch deltas between successive
+// broadcast states, so the server can push small updates to WebSocket
+// clients instead of the full config on every change.
+package delta
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Op is a single RFC 6902 JSON Patch operation. This server only ever emits
+// "add", "remove", and "replace" — enough to express any change between two
+// JSON values without needing "move"/"copy"/"test".
+type Op struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Snapshot is a full-state push, used on initial connect or when a client
+// requests a resync. Seq lets clients line it up against subsequent patches.
+type Snapshot struct {
+	Type string      `json:"type"`
+	Seq  uint64      `json:"seq"`
+	Data interface{} `json:"data"`
+}
+
+// Patch carries the ops needed to bring a client from the previous broadcast
+// state to the current one.
+type Patch struct {
+	Type string `json:"type"`
+	Seq  uint64 `json:"seq"`
+	Ops  []Op   `json:"ops"`
+}
+
+// Diff computes the ops that transform prev into next. Both are marshaled to
+// JSON and compared structurally, so any JSON-serializable value works.
+func Diff(prev, next interface{}) ([]Op, error) {
+	prevVal, err := toJSONValue(prev)
+	if err != nil {
+		return nil, fmt.Errorf("delta: encode prev: %w", err)
+	}
+	nextVal, err := toJSONValue(next)
+	if err != nil {
+		return nil, fmt.Errorf("delta: encode next: %w", err)
+	}
+
+	var ops []Op
+	diffValues("", prevVal, nextVal, &ops)
+	return ops, nil
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// diffValues recursively compares prev and next at path, appending the ops
+// needed to turn prev into next onto ops.
+func diffValues(path string, prev, next interface{}, ops *[]Op) {
+	prevMap, prevIsMap := prev.(map[string]interface{})
+	nextMap, nextIsMap := next.(map[string]interface{})
+	if prevIsMap && nextIsMap {
+		diffObjects(path, prevMap, nextMap, ops)
+		return
+	}
+
+	prevArr, prevIsArr := prev.([]interface{})
+	nextArr, nextIsArr := next.([]interface{})
+	if prevIsArr && nextIsArr {
+		diffArrays(path, prevArr, nextArr, ops)
+		return
+	}
+
+	// A page's chat history travels as a JSON-encoded string inside
+	// Component.Properties (a map[string]string), not as a native array, so
+	// it would otherwise hit the scalar case below and replace the whole
+	// string on every new message. Detect that shape and diff the decoded
+	// elements instead, so an appended message produces one small "add" op
+	// rather than a "replace" carrying the entire re-serialized history.
+	// Those ops address elements of the decoded array under the string's
+	// path, so a client decodes that property as JSON before applying them;
+	// a plain RFC 6902 applier cannot apply them to the raw string value.
+	prevStr, prevIsStr := prev.(string)
+	nextStr, nextIsStr := next.(string)
+	if prevIsStr && nextIsStr && prevStr != nextStr {
+		if prevElems, nextElems, ok := decodeJSONArrayStrings(prevStr, nextStr); ok {
+			diffArrays(path, prevElems, nextElems, ops)
+			return
+		}
+	}
+
+	if !jsonEqual(prev, next) {
+		if prev == nil {
+			*ops = append(*ops, Op{Op: "add", Path: path, Value: next})
+		} else {
+			*ops = append(*ops, Op{Op: "replace", Path: path, Value: next})
+		}
+	}
+}
+
+// diffArrays compares prev and next element-wise, recursing into each
+// shared index and emitting "add"/"remove" ops for a length difference.
+// Appended elements are emitted with a trailing "/-" path (RFC 6902's
+// end-of-array marker) so the common case of new messages being pushed
+// onto the end of a history produces one small op per message rather than
+// a single op replacing the entire array or string blob.
+func diffArrays(path string, prev, next []interface{}, ops *[]Op) {
+	common := len(prev)
+	if len(next) < common {
+		common = len(next)
+	}
+	for i := 0; i < common; i++ {
+		diffValues(fmt.Sprintf("%s/%d", path, i), prev[i], next[i], ops)
+	}
+
+	// Remove from the end first so earlier indices stay valid as each op is
+	// applied in order.
+	for i := len(prev) - 1; i >= len(next); i-- {
+		*ops = append(*ops, Op{Op: "remove", Path: fmt.Sprintf("%s/%d", path, i)})
+	}
+	for i := len(prev); i < len(next); i++ {
+		*ops = append(*ops, Op{Op: "add", Path: path + "/-", Value: next[i]})
+	}
+}
+
+// decodeJSONArrayStrings reports whether prev and next are both JSON-encoded
+// arrays carried inside a string field (as Component.Properties["messages"]
+// is), returning their decoded elements if so.
+func decodeJSONArrayStrings(prev, next string) ([]interface{}, []interface{}, bool) {
+	var prevVal, nextVal interface{}
+	if json.Unmarshal([]byte(prev), &prevVal) != nil {
+		return nil, nil, false
+	}
+	if json.Unmarshal([]byte(next), &nextVal) != nil {
+		return nil, nil, false
+	}
+	prevArr, prevOK := prevVal.([]interface{})
+	nextArr, nextOK := nextVal.([]interface{})
+	if !prevOK || !nextOK {
+		return nil, nil, false
+	}
+	return prevArr, nextArr, true
+}
+
+func diffObjects(path string, prev, next map[string]interface{}, ops *[]Op) {
+	keys := make(map[string]bool, len(prev)+len(next))
+	for k := range prev {
+		keys[k] = true
+	}
+	for k := range next {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		childPath := path + "/" + key
+		prevVal, inPrev := prev[key]
+		nextVal, inNext := next[key]
+
+		switch {
+		case inPrev && !inNext:
+			*ops = append(*ops, Op{Op: "remove", Path: childPath})
+		case !inPrev && inNext:
+			*ops = append(*ops, Op{Op: "add", Path: childPath, Value: nextVal})
+		default:
+			diffValues(childPath, prevVal, nextVal, ops)
+		}
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	aRaw, errA := json.Marshal(a)
+	bRaw, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aRaw) == string(bRaw)
+}
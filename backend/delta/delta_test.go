@@ -0,0 +1,123 @@
+package delta
+
+import "testing"
+
+func countOps(ops []Op, op string) int {
+	n := 0
+	for _, o := range ops {
+		if o.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDiffScalarFieldChange(t *testing.T) {
+	prev := map[string]interface{}{"theme": "light"}
+	next := map[string]interface{}{"theme": "dark"}
+
+	ops, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Op != "replace" || ops[0].Path != "/theme" {
+		t.Fatalf("expected a single replace at /theme, got %+v", ops)
+	}
+}
+
+func TestDiffArrayAppendIsIncremental(t *testing.T) {
+	prev := map[string]interface{}{
+		"messages": []interface{}{"hello"},
+	}
+	next := map[string]interface{}{
+		"messages": []interface{}{"hello", "world"},
+	}
+
+	ops, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	// A single append should produce one small "add" op, not a whole-array
+	// "replace" carrying both elements.
+	if countOps(ops, "replace") != 0 {
+		t.Fatalf("expected no replace ops for an append, got %+v", ops)
+	}
+	if len(ops) != 1 || ops[0].Op != "add" || ops[0].Path != "/messages/-" || ops[0].Value != "world" {
+		t.Fatalf("expected one add at /messages/-, got %+v", ops)
+	}
+}
+
+func TestDiffArrayRemovalFromEnd(t *testing.T) {
+	prev := map[string]interface{}{"messages": []interface{}{"a", "b", "c"}}
+	next := map[string]interface{}{"messages": []interface{}{"a"}}
+
+	ops, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	if len(ops) != 2 {
+		t.Fatalf("expected 2 remove ops, got %+v", ops)
+	}
+	// Removed in descending index order, so earlier indices stay valid as
+	// each op is applied.
+	if ops[0].Path != "/messages/2" || ops[1].Path != "/messages/1" {
+		t.Fatalf("expected removes in descending order, got %+v", ops)
+	}
+}
+
+func TestDiffJSONEncodedMessageHistoryIsIncremental(t *testing.T) {
+	// Mirrors Component.Properties["messages"]: a JSON-encoded array of chat
+	// messages carried as a string, not a native array.
+	prev := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"messages": `[{"id":"1","content":"hi"}]`,
+		},
+	}
+	next := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"messages": `[{"id":"1","content":"hi"},{"id":"2","content":"there"}]`,
+		},
+	}
+
+	ops, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	if countOps(ops, "replace") != 0 {
+		t.Fatalf("expected the new message to produce an add, not a whole-blob replace; got %+v", ops)
+	}
+	if len(ops) != 1 || ops[0].Op != "add" || ops[0].Path != "/properties/messages/-" {
+		t.Fatalf("expected one add at /properties/messages/-, got %+v", ops)
+	}
+}
+
+func TestDiffNonArrayStringsStillReplace(t *testing.T) {
+	prev := map[string]interface{}{"userName": "alice"}
+	next := map[string]interface{}{"userName": "bob"}
+
+	ops, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Op != "replace" || ops[0].Path != "/userName" {
+		t.Fatalf("expected a plain replace for a non-JSON-array string field, got %+v", ops)
+	}
+}
+
+func TestDiffNoChangesProducesNoOps(t *testing.T) {
+	state := map[string]interface{}{
+		"theme":    "dark",
+		"messages": []interface{}{"a", "b"},
+	}
+
+	ops, err := Diff(state, state)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(ops) != 0 {
+		t.Fatalf("expected no ops for identical states, got %+v", ops)
+	}
+}
@@ -0,0 +1,25 @@
+package delta
+
+import "sync"
+
+// Sequencer hands out a monotonically increasing sequence number per page,
+// so clients receiving Patch messages can detect a gap (and request a
+// resync) if they miss one.
+type Sequencer struct {
+	mu  sync.Mutex
+	seq map[string]uint64
+}
+
+// NewSequencer returns an empty Sequencer.
+func NewSequencer() *Sequencer {
+	return &Sequencer{seq: make(map[string]uint64)}
+}
+
+// Next returns the next sequence number for pageID, starting at 1.
+func (s *Sequencer) Next(pageID string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq[pageID]++
+	return s.seq[pageID]
+}
@@ -0,0 +1,23 @@
+// Package legacy translates between the current UIConfig broadcast format
+// and an older envelope-based WebSocket protocol, so that older frontends
+// can keep talking to /ws/v1 without the main handlers having to know
+// anything about the legacy wire format.
+package legacy
+
+import "encoding/json"
+
+// Envelope is the legacy message wrapper: {"event": "...", "payload": {...}}.
+type Envelope struct {
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// mustPayload marshals v into an Envelope's Payload, falling back to "null"
+// on error rather than panicking a live connection over a bad payload.
+func mustPayload(v interface{}) json.RawMessage {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return raw
+}
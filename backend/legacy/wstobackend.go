@@ -0,0 +1,49 @@
+package legacy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Backend is the set of server operations the legacy bridge invokes in
+// response to a translated client command. The caller supplies an
+// implementation backed by the real page store.
+type Backend interface {
+	SendMessage(pageID, sender, content string) error
+	SetTheme(pageID, theme, primaryColor string) error
+	ResetPage(pageID string) error
+}
+
+// HandleClientFrame decodes a raw WebSocket frame as a legacy Envelope and
+// routes it to the matching Backend method for pageID. sender is the caller's
+// authenticated user ID; it's used as the message author for "chat/message"
+// regardless of whatever the envelope payload itself claims, so a client
+// can't post chat history under another user's name.
+func HandleClientFrame(backend Backend, pageID, sender string, raw []byte) error {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("legacy: malformed envelope: %w", err)
+	}
+
+	switch env.Event {
+	case "chat/message":
+		var payload ChatMessagePayload
+		if err := json.Unmarshal(env.Payload, &payload); err != nil {
+			return fmt.Errorf("legacy: malformed chat/message payload: %w", err)
+		}
+		return backend.SendMessage(pageID, sender, payload.Content)
+
+	case "theme/update":
+		var payload ThemeUpdatePayload
+		if err := json.Unmarshal(env.Payload, &payload); err != nil {
+			return fmt.Errorf("legacy: malformed theme/update payload: %w", err)
+		}
+		return backend.SetTheme(pageID, payload.Theme, payload.PrimaryColor)
+
+	case "page/reset":
+		return backend.ResetPage(pageID)
+
+	default:
+		return fmt.Errorf("legacy: unknown event %q", env.Event)
+	}
+}
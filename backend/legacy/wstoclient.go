@@ -0,0 +1,65 @@
+package legacy
+
+import "time"
+
+// ThemeUpdatePayload is the payload carried by a "theme/update" event.
+type ThemeUpdatePayload struct {
+	PrimaryColor string `json:"primaryColor"`
+	Theme        string `json:"theme"`
+}
+
+// ChatMessagePayload is the payload carried by a "chat/message" event.
+type ChatMessagePayload struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Sender    string    `json:"sender"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UIState is the subset of current server state the legacy bridge needs in
+// order to build client-bound events. It's a plain struct rather than a
+// reference to the main package's UIConfig/PageConfig so this package has no
+// import back to main.
+type UIState struct {
+	Theme        string
+	PrimaryColor string
+	Messages     []ChatMessagePayload
+}
+
+// ToClientEnvelopes translates the current server state into the sequence of
+// legacy events an old frontend expects: one "theme/update" followed by one
+// "chat/message" per message in the history.
+func ToClientEnvelopes(state UIState) []Envelope {
+	envelopes, _ := ToClientEnvelopesSince(state, 0)
+	return envelopes
+}
+
+// ToClientEnvelopesSince is like ToClientEnvelopes, but only includes
+// "chat/message" events for messages beyond sentCount, so a long-lived
+// client that's already seen the earlier history isn't replayed the whole
+// thing on every update. It returns the sentCount to remember for next time.
+// If sentCount no longer fits state.Messages (e.g. the page was reset), the
+// full history is sent, same as a fresh client.
+func ToClientEnvelopesSince(state UIState, sentCount int) ([]Envelope, int) {
+	envelopes := []Envelope{
+		{
+			Event: "theme/update",
+			Payload: mustPayload(ThemeUpdatePayload{
+				PrimaryColor: state.PrimaryColor,
+				Theme:        state.Theme,
+			}),
+		},
+	}
+	if sentCount < 0 || sentCount > len(state.Messages) {
+		sentCount = 0
+	}
+	for _, msg := range state.Messages[sentCount:] {
+		envelopes = append(envelopes, Envelope{Event: "chat/message", Payload: mustPayload(msg)})
+	}
+	return envelopes, len(state.Messages)
+}
+
+// ResetEnvelope is the event sent to legacy clients when a page is reset.
+func ResetEnvelope() Envelope {
+	return Envelope{Event: "page/reset", Payload: mustPayload(struct{}{})}
+}
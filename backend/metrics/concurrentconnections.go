@@ -0,0 +1,29 @@
+package metrics
+
+import "log"
+
+// fdWarnThreshold is the fraction of the OS file-descriptor soft limit at
+// which CheckFDSoftLimit starts logging warnings. The actual syscall used
+// to read that limit differs by platform and lives in the
+// concurrentconnections_<os>.go files alongside this one.
+const fdWarnThreshold = 0.8
+
+// CheckFDSoftLimit compares current, the server's current count of tracked
+// connections, against the OS's soft file-descriptor limit, logging a
+// warning once current reaches fdWarnThreshold of it. Callers are expected
+// to invoke this whenever a new WebSocket connection is accepted.
+func CheckFDSoftLimit(current int64) {
+	limit, err := getFDSoftLimit()
+	if err != nil {
+		log.Printf("metrics: unable to read file descriptor limit: %v", err)
+		return
+	}
+	if limit <= 0 {
+		// Unknown or unbounded on this platform; nothing to warn about.
+		return
+	}
+
+	if float64(current) >= float64(limit)*fdWarnThreshold {
+		log.Printf("metrics: %d open connections has reached %.0f%% of the file descriptor soft limit (%d)", current, fdWarnThreshold*100, limit)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+
+package metrics
+
+import "syscall"
+
+// getFDSoftLimit reads RLIMIT_NOFILE's soft limit via getrlimit(2).
+func getFDSoftLimit() (int64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, err
+	}
+	return int64(rlimit.Cur), nil
+}
@@ -0,0 +1,17 @@
+//go:build freebsd
+
+package metrics
+
+import "syscall"
+
+// getFDSoftLimit reads RLIMIT_NOFILE's soft limit. FreeBSD's syscall
+// package reports Rlimit.Cur/Max as int64 rather than Linux's uint64, so
+// this gets its own file instead of sharing a conversion with
+// concurrentconnections_linux.go.
+func getFDSoftLimit() (int64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, err
+	}
+	return rlimit.Cur, nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package metrics
+
+// getFDSoftLimit has no equivalent on Windows: there is no RLIMIT_NOFILE or
+// getrlimit syscall, since handle accounting works differently than POSIX
+// file descriptors. Report 0 ("unknown") so CheckFDSoftLimit skips the
+// warning rather than acting on a made-up number.
+func getFDSoftLimit() (int64, error) {
+	return 0, nil
+}
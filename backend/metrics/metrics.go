@@ -0,0 +1,145 @@
+// Package metrics tracks server-side operational counters — active
+// WebSocket clients per page, bytes written, message throughput, and
+// rejected connections — and exposes them for the /api/metrics and
+// /metrics HTTP endpoints.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics aggregates the server's operational counters. The zero value is
+// not ready to use; call New instead.
+type Metrics struct {
+	mu            sync.Mutex
+	clientsByPage map[string]int64
+
+	bytesWritten        int64
+	messagesAppended    int64
+	rejectedConnections int64
+}
+
+// New returns an empty Metrics ready for use.
+func New() *Metrics {
+	return &Metrics{clientsByPage: make(map[string]int64)}
+}
+
+// ClientConnected records a WebSocket client subscribing to pageID.
+func (m *Metrics) ClientConnected(pageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clientsByPage[pageID]++
+}
+
+// ClientDisconnected records a WebSocket client leaving pageID.
+func (m *Metrics) ClientDisconnected(pageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clientsByPage[pageID] > 0 {
+		m.clientsByPage[pageID]--
+	}
+	if m.clientsByPage[pageID] == 0 {
+		delete(m.clientsByPage, pageID)
+	}
+}
+
+// ClientsForPage returns the current number of connected clients for pageID.
+func (m *Metrics) ClientsForPage(pageID string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clientsByPage[pageID]
+}
+
+// TotalClients returns the current number of connected clients across all
+// pages.
+func (m *Metrics) TotalClients() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for _, n := range m.clientsByPage {
+		total += n
+	}
+	return total
+}
+
+// AddBytesWritten adds n to the running total of bytes written to clients.
+func (m *Metrics) AddBytesWritten(n int) {
+	atomic.AddInt64(&m.bytesWritten, int64(n))
+}
+
+// MessageAppended records one chat message being appended to a page.
+func (m *Metrics) MessageAppended() {
+	atomic.AddInt64(&m.messagesAppended, 1)
+}
+
+// ConnectionRejected records a WebSocket connection refused because a limit
+// was exceeded.
+func (m *Metrics) ConnectionRejected() {
+	atomic.AddInt64(&m.rejectedConnections, 1)
+}
+
+// Snapshot is a point-in-time copy of the counters, suitable for
+// JSON-encoding or translating into Prometheus samples.
+type Snapshot struct {
+	ClientsByPage       map[string]int64 `json:"clientsByPage"`
+	TotalClients        int64            `json:"totalClients"`
+	BytesWritten        int64            `json:"bytesWritten"`
+	MessagesAppended    int64            `json:"messagesAppended"`
+	RejectedConnections int64            `json:"rejectedConnections"`
+}
+
+// Snapshot returns a copy of the current counter values.
+func (m *Metrics) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byPage := make(map[string]int64, len(m.clientsByPage))
+	var total int64
+	for page, n := range m.clientsByPage {
+		byPage[page] = n
+		total += n
+	}
+
+	return Snapshot{
+		ClientsByPage:       byPage,
+		TotalClients:        total,
+		BytesWritten:        atomic.LoadInt64(&m.bytesWritten),
+		MessagesAppended:    atomic.LoadInt64(&m.messagesAppended),
+		RejectedConnections: atomic.LoadInt64(&m.rejectedConnections),
+	}
+}
+
+// WritePrometheus writes the current counters to w in Prometheus text
+// exposition format, so /metrics can be scraped without pulling in a
+// client library.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	snap := m.Snapshot()
+
+	fmt.Fprintln(w, "# HELP server_ui_ws_clients Active WebSocket clients per page.")
+	fmt.Fprintln(w, "# TYPE server_ui_ws_clients gauge")
+	for page, n := range snap.ClientsByPage {
+		fmt.Fprintf(w, "server_ui_ws_clients{page=%q} %d\n", page, n)
+	}
+
+	fmt.Fprintln(w, "# HELP server_ui_ws_clients_total Total active WebSocket clients across all pages.")
+	fmt.Fprintln(w, "# TYPE server_ui_ws_clients_total gauge")
+	fmt.Fprintf(w, "server_ui_ws_clients_total %d\n", snap.TotalClients)
+
+	fmt.Fprintln(w, "# HELP server_ui_bytes_written_total Total bytes written to WebSocket clients.")
+	fmt.Fprintln(w, "# TYPE server_ui_bytes_written_total counter")
+	fmt.Fprintf(w, "server_ui_bytes_written_total %d\n", snap.BytesWritten)
+
+	fmt.Fprintln(w, "# HELP server_ui_messages_appended_total Total chat messages appended across all pages.")
+	fmt.Fprintln(w, "# TYPE server_ui_messages_appended_total counter")
+	fmt.Fprintf(w, "server_ui_messages_appended_total %d\n", snap.MessagesAppended)
+
+	fmt.Fprintln(w, "# HELP server_ui_connections_rejected_total WebSocket connections rejected for exceeding a limit.")
+	fmt.Fprintln(w, "# TYPE server_ui_connections_rejected_total counter")
+	fmt.Fprintf(w, "server_ui_connections_rejected_total %d\n", snap.RejectedConnections)
+
+	return nil
+}
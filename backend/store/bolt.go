@@ -0,0 +1,105 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// pagesBucket is the single BoltDB bucket holding JSON-encoded Page values,
+// keyed by page ID.
+var pagesBucket = []byte("pages")
+
+// BoltStore is a Store backed by a BoltDB file. It's the recommended option
+// for single-process deployments that want durability without running a
+// separate database server.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path and
+// returns a Store backed by it.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("store: open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pagesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: create pages bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) GetPage(pageID string) (Page, error) {
+	var page Page
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(pagesBucket).Get([]byte(pageID))
+		if raw == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(raw, &page)
+	})
+	return page, err
+}
+
+func (s *BoltStore) PutPage(pageID string, page Page) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		raw, err := json.Marshal(page)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(pagesBucket).Put([]byte(pageID), raw)
+	})
+}
+
+func (s *BoltStore) ListPages() ([]Page, error) {
+	var pages []Page
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(pagesBucket).ForEach(func(_, raw []byte) error {
+			var page Page
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			pages = append(pages, page)
+			return nil
+		})
+	})
+	return pages, err
+}
+
+func (s *BoltStore) AppendMessage(pageID string, msg Message) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(pagesBucket)
+		raw := b.Get([]byte(pageID))
+		if raw == nil {
+			return ErrNotFound
+		}
+		var page Page
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		page.Config.Messages = append(page.Config.Messages, msg)
+		updated, err := json.Marshal(page)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(pageID), updated)
+	})
+}
+
+func (s *BoltStore) ResetPage(pageID string, defaults Page) error {
+	return s.PutPage(pageID, defaults)
+}
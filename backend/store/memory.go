@@ -0,0 +1,66 @@
+package store
+
+import "sync"
+
+// MemoryStore is an in-memory Store. It matches the server's original
+// behavior: state lives only for the lifetime of the process.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	pages map[string]Page
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{pages: make(map[string]Page)}
+}
+
+func (s *MemoryStore) GetPage(pageID string) (Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page, ok := s.pages[pageID]
+	if !ok {
+		return Page{}, ErrNotFound
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) PutPage(pageID string, page Page) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages[pageID] = page
+	return nil
+}
+
+func (s *MemoryStore) ListPages() ([]Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pages := make([]Page, 0, len(s.pages))
+	for _, page := range s.pages {
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+func (s *MemoryStore) AppendMessage(pageID string, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, ok := s.pages[pageID]
+	if !ok {
+		return ErrNotFound
+	}
+	page.Config.Messages = append(page.Config.Messages, msg)
+	s.pages[pageID] = page
+	return nil
+}
+
+func (s *MemoryStore) ResetPage(pageID string, defaults Page) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages[pageID] = defaults
+	return nil
+}
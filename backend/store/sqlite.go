@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteBusyTimeoutMS bounds how long a write waits on SQLite's single
+// writer lock before giving up. Without it, any second concurrent writer
+// gets "database is locked" immediately instead of queuing behind the first.
+const sqliteBusyTimeoutMS = 5000
+
+// SQLiteStore is a Store backed by a SQLite database via database/sql. Pages
+// are stored as a single JSON blob per row, which keeps the schema simple at
+// the cost of not being queryable by field — fine for this server's access
+// patterns, which are always by pageID.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at dsn and
+// returns a Store backed by it.
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", withBusyTimeout(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("store: open sqlite db: %w", err)
+	}
+	// database/sql pools connections, but SQLite only allows one writer at a
+	// time; a second connection attempting a concurrent write would just
+	// queue behind the busy_timeout and then fail anyway. Capping the pool
+	// at one connection serializes writers through database/sql instead,
+	// so AppendMessage/PutPage calls queue rather than hit SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	const schema = `
+	CREATE TABLE IF NOT EXISTS pages (
+		page_id TEXT PRIMARY KEY,
+		data    TEXT NOT NULL
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: create pages table: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// withBusyTimeout appends a _busy_timeout query parameter to dsn if it
+// doesn't already set one, so SQLITE_BUSY errors from lock contention are
+// retried internally by the driver instead of surfacing immediately.
+func withBusyTimeout(dsn string) string {
+	if strings.Contains(dsn, "_busy_timeout=") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s_busy_timeout=%d", dsn, sep, sqliteBusyTimeoutMS)
+}
+
+// Close releases the underlying database connection.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) GetPage(pageID string) (Page, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT data FROM pages WHERE page_id = ?`, pageID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return Page{}, ErrNotFound
+	}
+	if err != nil {
+		return Page{}, fmt.Errorf("store: get page: %w", err)
+	}
+
+	var page Page
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return Page{}, fmt.Errorf("store: decode page: %w", err)
+	}
+	return page, nil
+}
+
+func (s *SQLiteStore) PutPage(pageID string, page Page) error {
+	raw, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("store: encode page: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO pages (page_id, data) VALUES (?, ?)
+		ON CONFLICT(page_id) DO UPDATE SET data = excluded.data
+	`, pageID, string(raw))
+	if err != nil {
+		return fmt.Errorf("store: put page: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListPages() ([]Page, error) {
+	rows, err := s.db.Query(`SELECT data FROM pages`)
+	if err != nil {
+		return nil, fmt.Errorf("store: list pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []Page
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("store: scan page: %w", err)
+		}
+		var page Page
+		if err := json.Unmarshal([]byte(raw), &page); err != nil {
+			return nil, fmt.Errorf("store: decode page: %w", err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
+
+// AppendMessage reads and rewrites a page's full Messages slice within a
+// single transaction, so two concurrent appends to the same page (e.g.
+// multiple chat clients posting at once) can't race and silently drop one.
+func (s *SQLiteStore) AppendMessage(pageID string, msg Message) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: begin append: %w", err)
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRow(`SELECT data FROM pages WHERE page_id = ?`, pageID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("store: get page: %w", err)
+	}
+
+	var page Page
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return fmt.Errorf("store: decode page: %w", err)
+	}
+	page.Config.Messages = append(page.Config.Messages, msg)
+
+	updated, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("store: encode page: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE pages SET data = ? WHERE page_id = ?`, string(updated), pageID); err != nil {
+		return fmt.Errorf("store: put page: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) ResetPage(pageID string, defaults Page) error {
+	return s.PutPage(pageID, defaults)
+}
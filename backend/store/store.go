@@ -0,0 +1,27 @@
+package store
+
+import "errors"
+
+// ErrNotFound is returned by Store methods when the requested page does not exist.
+var ErrNotFound = errors.New("store: page not found")
+
+// Store persists Page configurations and their chat messages. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// GetPage returns the page for pageID, or ErrNotFound if it doesn't exist.
+	GetPage(pageID string) (Page, error)
+
+	// PutPage creates or replaces the page at pageID.
+	PutPage(pageID string, page Page) error
+
+	// ListPages returns every stored page.
+	ListPages() ([]Page, error)
+
+	// AppendMessage appends msg to the page's message history without
+	// touching the rest of its configuration. It is an error to append to a
+	// page that does not yet exist.
+	AppendMessage(pageID string, msg Message) error
+
+	// ResetPage restores the page at pageID to the given default configuration.
+	ResetPage(pageID string, defaults Page) error
+}
@@ -0,0 +1,64 @@
+// Package store defines the persistence layer for page configurations and
+// chat messages, along with in-memory, BoltDB, and SQLite implementations.
+package store
+
+import "time"
+
+// ChatUser represents a user in the chat system with their basic information.
+type ChatUser struct {
+	Name   string `json:"name"`   // Display name of the user
+	Status string `json:"status"` // Online status (Online/Offline/Away)
+	Avatar string `json:"avatar"` // URL to user's avatar image
+}
+
+// Message represents a single chat message in the system.
+type Message struct {
+	ID        string    `json:"id"`        // Unique identifier for the message
+	Content   string    `json:"content"`   // Message content
+	Sender    string    `json:"sender"`    // Name of message sender
+	Timestamp time.Time `json:"timestamp"` // Time when message was sent
+}
+
+// SharedConfig represents the core configuration shared across a chat instance.
+// It contains all necessary information for rendering the chat interface and managing messages.
+type SharedConfig struct {
+	DisplayMessage string    `json:"message"`     // Message to be displayed in the chat header
+	CurrentColor   string    `json:"color"`       // Current theme color
+	Theme          string    `json:"theme"`       // UI theme (light/dark)
+	ChatPartner    ChatUser  `json:"chatPartner"` // Information about the chat partner
+	Messages       []Message `json:"messages"`    // Array of chat messages
+}
+
+// ACL controls who may read or modify a Page.
+type ACL struct {
+	Owner        string   `json:"owner"`
+	AllowedUsers []string `json:"allowedUsers"`
+}
+
+// Allows reports whether userID may access a page protected by acl.
+// An ACL with no owner set is treated as unclaimed/public.
+func (acl ACL) Allows(userID string) bool {
+	if acl.Owner == "" || acl.Owner == userID {
+		return true
+	}
+	for _, id := range acl.AllowedUsers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Page is the persisted record for a single chat page: its display metadata,
+// its configuration, the ACL guarding access to it, and its webhook wiring.
+type Page struct {
+	PageID      string       `json:"pageId"`      // Unique page identifier
+	DisplayName string       `json:"displayName"` // Human-readable page name
+	Config      SharedConfig `json:"config"`      // Page-specific configuration
+	ACL         ACL          `json:"acl"`          // Owner and allowed users for this page
+
+	InboundToken     string   `json:"inboundToken"`     // Secret path token for the inbound webhook endpoint
+	WebhookSecret    string   `json:"webhookSecret"`    // HMAC secret used to sign outgoing webhook deliveries
+	OutgoingWebhooks []string `json:"outgoingWebhooks"` // URLs notified on message append or config change
+}
+
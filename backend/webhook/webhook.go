@@ -0,0 +1,173 @@
+// Package webhook delivers signed, retried HTTP callbacks to per-page
+// outgoing webhook URLs whenever a page's messages or configuration change.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	maxAttempts    = 5
+	initialBackoff = time.Second
+	deliverTimeout = 10 * time.Second
+	queueSize      = 256
+)
+
+// Event is the envelope POSTed to outgoing webhook URLs.
+type Event struct {
+	PageID    string          `json:"pageId"`
+	Type      string          `json:"type"` // "message" or "config"
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+type delivery struct {
+	url    string
+	secret string
+	body   []byte
+}
+
+// Dispatcher delivers webhook events in the background, retrying failed
+// deliveries with exponential backoff. It must be constructed with NewDispatcher.
+type Dispatcher struct {
+	client *http.Client
+	jobs   chan delivery
+}
+
+// NewDispatcher starts a Dispatcher backed by workers background goroutines.
+func NewDispatcher(workers int) *Dispatcher {
+	d := &Dispatcher{
+		client: &http.Client{
+			Timeout:       deliverTimeout,
+			Transport:     &http.Transport{DialContext: safeDialContext},
+			CheckRedirect: refuseRedirect,
+		},
+		jobs: make(chan delivery, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// refuseRedirect makes the Dispatcher's client never follow a redirect:
+// the URL a page owner configured is the only one that was ever validated
+// (by the caller, before storing it), so a 3xx response pointing somewhere
+// else must not be followed automatically.
+func refuseRedirect(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// safeDialContext is the Dispatcher client's Transport.DialContext. A
+// webhook URL is validated once, when a page owner configures it, but that
+// validation only proves the hostname resolved to a public IP at that
+// moment — a subsequent lookup for the same hostname can return a different
+// (private or loopback) address, a DNS rebinding attack that would otherwise
+// let the real delivery reach internal services the earlier check rejected.
+// Re-resolving and re-checking every address here, then dialing the
+// validated IP directly instead of addr, closes that window: the transport
+// never performs a second, unvalidated lookup of its own.
+func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: could not resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip.IP) {
+			return nil, fmt.Errorf("webhook: refusing to dial private or loopback address %s", ip.IP)
+		}
+	}
+	dialer := &net.Dialer{Timeout: deliverTimeout}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// IsPublicIP reports whether ip is safe for this server to connect out to:
+// not loopback, link-local, private-range, or unspecified. Shared by the
+// config-time URL validation and this package's own dial-time check, so
+// both sides of the SSRF guard agree on what counts as "private".
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsPrivate() && !ip.IsUnspecified()
+}
+
+// Send enqueues event for delivery to url, signed with secret. It returns
+// immediately; delivery (and any retries) happen on a background worker. If
+// the queue is full the event is dropped and logged, rather than blocking
+// the caller.
+func (d *Dispatcher) Send(url, secret string, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhook: failed to encode event for %s: %v", url, err)
+		return
+	}
+
+	select {
+	case d.jobs <- delivery{url: url, secret: secret, body: body}:
+	default:
+		log.Printf("webhook: queue full, dropping delivery to %s", url)
+	}
+}
+
+func (d *Dispatcher) worker() {
+	for job := range d.jobs {
+		d.deliverWithRetry(job)
+	}
+}
+
+func (d *Dispatcher) deliverWithRetry(job delivery) {
+	backoff := initialBackoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if d.deliver(job) {
+			return
+		}
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	log.Printf("webhook: giving up delivering to %s after %d attempts", job.url, maxAttempts)
+}
+
+func (d *Dispatcher) deliver(job delivery) bool {
+	req, err := http.NewRequest(http.MethodPost, job.url, bytes.NewReader(job.body))
+	if err != nil {
+		log.Printf("webhook: failed to build request for %s: %v", job.url, err)
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", Sign(job.secret, job.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		log.Printf("webhook: delivery to %s failed: %v", job.url, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("webhook: delivery to %s returned status %d", job.url, resp.StatusCode)
+		return false
+	}
+	return true
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of payload keyed by secret, used
+// as the X-Signature header on outgoing deliveries.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}